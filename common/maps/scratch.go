@@ -0,0 +1,322 @@
+// Copyright 2015 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package maps holds small helper types that are shared across Hugo's
+// packages, so they don't have to live behind a single higher-level package
+// such as hugolib. Its only Hugo dependency is the helpers package.
+package maps
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/spf13/hugo/helpers"
+)
+
+// Scratch is a writable context used for stateful operations in Page/Node/Site
+// rendering. It is safe for concurrent use.
+type Scratch struct {
+	values map[string]interface{}
+	mu     sync.RWMutex
+}
+
+// For single values, Add will add (using the + operator) the addend to the existing addend (if found).
+// Supports numeric values and strings.
+//
+// If the first add for a key is an array or slice, then the next value(s) will be appended.
+func (c *Scratch) Add(key string, newAddend interface{}) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var newVal interface{}
+	existingAddend, found := c.values[key]
+	if found {
+		var err error
+
+		addendV := reflect.ValueOf(existingAddend)
+
+		if addendV.Kind() == reflect.Slice || addendV.Kind() == reflect.Array {
+			newVal = appendSlice(addendV, newAddend)
+		} else {
+			newVal, err = helpers.DoArithmetic(existingAddend, newAddend, '+')
+			if err != nil {
+				return "", err
+			}
+		}
+	} else {
+		newVal = newAddend
+	}
+	c.values[key] = newVal
+	return "", nil // have to return something to make it work with the Go templates
+}
+
+// Sub subtracts the given value from the existing value of key, using the
+// '-' operator. See Add for the numeric-vs-slice distinction; unlike Add, Sub
+// only makes sense for numeric values, so it does not fall back to appending.
+func (c *Scratch) Sub(key string, value interface{}) (string, error) {
+	return c.doMath(key, value, '-')
+}
+
+// Mul multiplies the existing value of key by the given value, using the
+// '*' operator.
+func (c *Scratch) Mul(key string, value interface{}) (string, error) {
+	return c.doMath(key, value, '*')
+}
+
+// Div divides the existing value of key by the given value, using the
+// '/' operator.
+func (c *Scratch) Div(key string, value interface{}) (string, error) {
+	return c.doMath(key, value, '/')
+}
+
+// doMath runs op against the existing value stored at key and the given
+// value and stores the result at key. Like Add, it returns an empty string
+// so it can be used from Go templates. It is the shared implementation
+// behind Sub, Mul and Div.
+func (c *Scratch) doMath(key string, value interface{}, op rune) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, found := c.values[key]
+	if !found {
+		return "", fmt.Errorf("unable to find existing value for key %q", key)
+	}
+
+	newVal, err := helpers.DoArithmetic(existing, value, op)
+	if err != nil {
+		return "", err
+	}
+	c.values[key] = newVal
+	return "", nil
+}
+
+// Append appends the given value(s) to a slice stored at key, creating the
+// slice if it isn't already set. Use this instead of Add when the existing
+// value's type is ambiguous (e.g. an empty Scratch or an interface{} zero
+// value) and a numeric Add could be misread as slice-append or vice versa.
+func (c *Scratch) Append(key string, newAddend interface{}) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, found := c.values[key]
+	if !found {
+		c.values[key] = newSlice(newAddend)
+		return "", nil
+	}
+
+	c.values[key] = appendSlice(reflect.ValueOf(existing), newAddend)
+	return "", nil
+}
+
+// newSlice wraps newAddend in a single-element []interface{}, unless it is
+// itself already a slice or array, in which case it is used as-is.
+func newSlice(newAddend interface{}) interface{} {
+	nav := reflect.ValueOf(newAddend)
+	if nav.Kind() == reflect.Slice || nav.Kind() == reflect.Array {
+		return newAddend
+	}
+	return []interface{}{newAddend}
+}
+
+// appendSlice appends newAddend to the slice or array held by existingV,
+// flattening newAddend into the result if it is itself a slice or array.
+func appendSlice(existingV reflect.Value, newAddend interface{}) interface{} {
+	nav := reflect.ValueOf(newAddend)
+	if nav.Kind() == reflect.Slice || nav.Kind() == reflect.Array {
+		return reflect.AppendSlice(existingV, nav).Interface()
+	}
+	return reflect.Append(existingV, nav).Interface()
+}
+
+// Set stores a value with the given key in the Node context.
+// This value can later be retrieved with Get.
+func (c *Scratch) Set(key string, value interface{}) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] = value
+	return ""
+}
+
+// Get returns a value previously set by Add or Set
+func (c *Scratch) Get(key string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.values[key]
+}
+
+// SetIfNotSet stores value with the given key and returns true if, and only
+// if, no value was already stored for that key. The check and the set happen
+// under a single write lock, so it is safe to use from parallel shortcode or
+// partial execution where the equivalent
+// {{ if not (.Scratch.Get "x") }}{{ .Scratch.Set "x" ... }}{{ end }}
+// template pattern would race.
+func (c *Scratch) SetIfNotSet(key string, value interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, found := c.values[key]; found {
+		return false
+	}
+	c.values[key] = value
+	return true
+}
+
+// GetOrCreate returns the value stored at key, calling create and storing
+// its result first if no value is stored yet. The check and the set happen
+// under a single write lock, so create is guaranteed to run at most once per
+// key even when called concurrently for the same key.
+func (c *Scratch) GetOrCreate(key string, create func() interface{}) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if value, found := c.values[key]; found {
+		return value
+	}
+	value := create()
+	c.values[key] = value
+	return value
+}
+
+// GetInt returns the value previously set by Add or Set as an int, or 0 if
+// it either was not found or was not an int. No conversion is done, so other
+// numeric types such as int64 also give 0.
+func (c *Scratch) GetInt(key string) int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	val, ok := c.values[key].(int)
+	if !ok {
+		return 0
+	}
+	return val
+}
+
+// GetString returns the value previously set by Add or Set as a string, or
+// the empty string if it either was not found or was not a string.
+func (c *Scratch) GetString(key string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	val, ok := c.values[key].(string)
+	if !ok {
+		return ""
+	}
+	return val
+}
+
+// GetSlice returns the value previously set by Add or Set as a
+// []interface{}, or nil if it either was not found or was not a
+// []interface{}. Slices of other element types, such as []string, also
+// give nil.
+func (c *Scratch) GetSlice(key string) []interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	val, ok := c.values[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	return val
+}
+
+// GetMap returns the value previously set by SetInMap as a
+// map[string]interface{}, or nil if it either was not found or was not a map.
+func (c *Scratch) GetMap(key string) map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	val, ok := c.values[key].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	return val
+}
+
+// SetInMap stores a value to a map with the given key in the Node context.
+// This map can later be retrieved with GetSortedMapValues.
+func (c *Scratch) SetInMap(key string, mapKey string, value interface{}) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, found := c.values[key]
+	if !found {
+		c.values[key] = make(map[string]interface{})
+	}
+
+	c.values[key].(map[string]interface{})[mapKey] = value
+	return ""
+}
+
+// Delete deletes the given key.
+func (c *Scratch) Delete(key string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.values, key)
+	return ""
+}
+
+// DeleteInMap deletes a key from a map previously created with SetInMap.
+func (c *Scratch) DeleteInMap(key string, mapKey string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if unsortedMap, found := c.values[key].(map[string]interface{}); found {
+		delete(unsortedMap, mapKey)
+	}
+	return ""
+}
+
+// Reset clears the Scratch instance, removing all values that have been set.
+func (c *Scratch) Reset() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values = make(map[string]interface{})
+	return ""
+}
+
+// GetSortedMapValues returns a sorted map previously filled with SetInMap
+func (c *Scratch) GetSortedMapValues(key string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.values[key] == nil {
+		return nil
+	}
+
+	unsortedMap := c.values[key].(map[string]interface{})
+
+	var keys []string
+	for mapKey := range unsortedMap {
+		keys = append(keys, mapKey)
+	}
+
+	sort.Strings(keys)
+
+	sortedArray := make([]interface{}, len(unsortedMap))
+	for i, mapKey := range keys {
+		sortedArray[i] = unsortedMap[mapKey]
+	}
+
+	return sortedArray
+}
+
+// NewScratch returns a new instance of Scratch.
+func NewScratch() *Scratch {
+	return &Scratch{values: make(map[string]interface{})}
+}
@@ -0,0 +1,177 @@
+// Copyright 2015 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestScratchDelete(t *testing.T) {
+	scratch := NewScratch()
+	scratch.Set("key", "val")
+
+	scratch.Delete("key")
+
+	if scratch.Get("key") != nil {
+		t.Errorf("Expected Get to return nil after Delete, got %v", scratch.Get("key"))
+	}
+}
+
+func TestScratchDeleteInMap(t *testing.T) {
+	scratch := NewScratch()
+	scratch.SetInMap("key", "a", "valA")
+	scratch.SetInMap("key", "b", "valB")
+
+	scratch.DeleteInMap("key", "a")
+
+	m := scratch.GetMap("key")
+	if _, found := m["a"]; found {
+		t.Error("Expected \"a\" to have been deleted from the map")
+	}
+	if m["b"] != "valB" {
+		t.Errorf("Expected \"b\" to be unaffected, got %v", m["b"])
+	}
+}
+
+func TestScratchDeleteInMapMissingKey(t *testing.T) {
+	scratch := NewScratch()
+
+	// Deleting from a map that was never created should be a no-op, not a panic.
+	scratch.DeleteInMap("key", "a")
+
+	if scratch.GetMap("key") != nil {
+		t.Errorf("Expected no map to have been created, got %v", scratch.GetMap("key"))
+	}
+}
+
+func TestScratchMath(t *testing.T) {
+	tests := []struct {
+		op       func(*Scratch, string, interface{}) (string, error)
+		initial  interface{}
+		operand  interface{}
+		expected interface{}
+	}{
+		{(*Scratch).Sub, int64(10), int64(3), int64(7)},
+		{(*Scratch).Mul, int64(10), int64(3), int64(30)},
+		{(*Scratch).Div, int64(10), int64(2), int64(5)},
+	}
+
+	for _, tc := range tests {
+		scratch := NewScratch()
+		scratch.Set("key", tc.initial)
+
+		if _, err := tc.op(scratch, "key", tc.operand); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if got := fmt.Sprintf("%v", scratch.Get("key")); got != fmt.Sprintf("%v", tc.expected) {
+			t.Errorf("expected %v, got %v", tc.expected, scratch.Get("key"))
+		}
+	}
+}
+
+func TestScratchMathMissingKey(t *testing.T) {
+	ops := []func(*Scratch, string, interface{}) (string, error){
+		(*Scratch).Sub, (*Scratch).Mul, (*Scratch).Div,
+	}
+
+	for _, op := range ops {
+		scratch := NewScratch()
+
+		if _, err := op(scratch, "key", int64(1)); err == nil {
+			t.Error("expected an error when the key has no existing value")
+		}
+	}
+}
+
+func TestScratchAppend(t *testing.T) {
+	scratch := NewScratch()
+
+	if _, err := scratch.Append("key", "a"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := scratch.Append("key", "b"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	slice := scratch.GetSlice("key")
+	if len(slice) != 2 || slice[0] != "a" || slice[1] != "b" {
+		t.Errorf("expected [a b], got %v", slice)
+	}
+}
+
+func TestScratchReset(t *testing.T) {
+	scratch := NewScratch()
+	scratch.Set("key1", "val1")
+	scratch.Set("key2", "val2")
+
+	scratch.Reset()
+
+	if scratch.Get("key1") != nil || scratch.Get("key2") != nil {
+		t.Error("Expected Reset to clear all previously set keys")
+	}
+}
+
+func TestScratchSetIfNotSetConcurrent(t *testing.T) {
+	const n = 50
+	scratch := NewScratch()
+
+	var wg sync.WaitGroup
+	var winners int32
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if scratch.SetIfNotSet("key", i) {
+				atomic.AddInt32(&winners, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if winners != 1 {
+		t.Errorf("expected exactly one goroutine to win SetIfNotSet, got %d", winners)
+	}
+}
+
+func TestScratchGetOrCreateConcurrent(t *testing.T) {
+	const n = 50
+	scratch := NewScratch()
+
+	var wg sync.WaitGroup
+	var created int32
+
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scratch.GetOrCreate("key", func() interface{} {
+				atomic.AddInt32(&created, 1)
+				return "value"
+			})
+		}()
+	}
+	wg.Wait()
+
+	if created != 1 {
+		t.Errorf("expected the factory to run exactly once, ran %d times", created)
+	}
+	if scratch.Get("key") != "value" {
+		t.Errorf("expected the stored value to be \"value\", got %v", scratch.Get("key"))
+	}
+}
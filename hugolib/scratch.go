@@ -14,112 +14,15 @@
 package hugolib
 
 import (
-	"github.com/spf13/hugo/helpers"
-	"reflect"
-	"sort"
-	"sync"
+	"github.com/spf13/hugo/common/maps"
 )
 
-// Scratch is a writable context used for stateful operations in Page/Node rendering.
-type Scratch struct {
-	values map[string]interface{}
-	mu     sync.RWMutex
-}
-
-// For single values, Add will add (using the + operator) the addend to the existing addend (if found).
-// Supports numeric values and strings.
-//
-// If the first add for a key is an array or slice, then the next value(s) will be appended.
-func (c *Scratch) Add(key string, newAddend interface{}) (string, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	var newVal interface{}
-	existingAddend, found := c.values[key]
-	if found {
-		var err error
-
-		addendV := reflect.ValueOf(existingAddend)
-
-		if addendV.Kind() == reflect.Slice || addendV.Kind() == reflect.Array {
-			nav := reflect.ValueOf(newAddend)
-			if nav.Kind() == reflect.Slice || nav.Kind() == reflect.Array {
-				newVal = reflect.AppendSlice(addendV, nav).Interface()
-			} else {
-				newVal = reflect.Append(addendV, nav).Interface()
-			}
-		} else {
-			newVal, err = helpers.DoArithmetic(existingAddend, newAddend, '+')
-			if err != nil {
-				return "", err
-			}
-		}
-	} else {
-		newVal = newAddend
-	}
-	c.values[key] = newVal
-	return "", nil // have to return something to make it work with the Go templates
-}
-
-// Set stores a value with the given key in the Node context.
-// This value can later be retrieved with Get.
-func (c *Scratch) Set(key string, value interface{}) string {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	c.values[key] = value
-	return ""
-}
-
-// Get returns a value previously set by Add or Set
-func (c *Scratch) Get(key string) interface{} {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	return c.values[key]
-}
-
-// SetInMap stores a value to a map with the given key in the Node context.
-// This map can later be retrieved with GetSortedMapValues.
-func (c *Scratch) SetInMap(key string, mapKey string, value interface{}) string {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	_, found := c.values[key]
-	if !found {
-		c.values[key] = make(map[string]interface{})
-	}
-
-	c.values[key].(map[string]interface{})[mapKey] = value
-	return ""
-}
-
-// GetSortedMapValues returns a sorted map previously filled with SetInMap
-func (c *Scratch) GetSortedMapValues(key string) interface{} {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	if c.values[key] == nil {
-		return nil
-	}
-
-	unsortedMap := c.values[key].(map[string]interface{})
-
-	var keys []string
-	for mapKey := range unsortedMap {
-		keys = append(keys, mapKey)
-	}
-
-	sort.Strings(keys)
-
-	sortedArray := make([]interface{}, len(unsortedMap))
-	for i, mapKey := range keys {
-		sortedArray[i] = unsortedMap[mapKey]
-	}
-
-	return sortedArray
-}
+// Scratch is a writable context used for stateful operations in Page/Node
+// rendering. The type itself now lives in common/maps so it can be embedded
+// by any renderable object, not just pages; this alias keeps the hugolib API
+// unchanged for existing callers.
+type Scratch = maps.Scratch
 
 func newScratch() *Scratch {
-	return &Scratch{values: make(map[string]interface{})}
-}
\ No newline at end of file
+	return maps.NewScratch()
+}
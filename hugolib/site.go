@@ -0,0 +1,37 @@
+// Copyright 2015 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugolib
+
+import "sync"
+
+// Site holds the site-wide state shared across every Page rendered from a
+// single configuration. It is deliberately minimal here: this snapshot of
+// hugolib only carries what's needed to host a site-scoped Scratch, not the
+// full site/page rendering pipeline.
+type Site struct {
+	scratch     *Scratch
+	scratchInit sync.Once
+}
+
+// Scratch returns the site-wide Scratch, exposed to templates as
+// .Site.Scratch. It is created lazily on first use and is safe to call
+// concurrently from parallel page rendering; every caller across every page
+// shares the same instance, so values set in one page's template are visible
+// to all others.
+func (s *Site) Scratch() *Scratch {
+	s.scratchInit.Do(func() {
+		s.scratch = newScratch()
+	})
+	return s.scratch
+}